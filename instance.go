@@ -0,0 +1,67 @@
+package soforo
+
+import (
+	"fmt"
+	"time"
+)
+
+// InstanceOpener is an optional interface that a Driver may implement to
+// construct a Repository from an already-live client instance (e.g. a
+// *sql.DB, *redis.Client, or a cloud SDK client) instead of a URL. This lets
+// applications reuse an already-authenticated client, or inject a mock/fake
+// for tests, without forcing all connection state into a DSN.
+type InstanceOpener[T any, R Repository] interface {
+	OpenInstance(instance T, cfg interface{}) (R, error)
+}
+
+// OpenInstance opens a Repository from the driver registered under name by
+// handing it an already-constructed instance rather than a URL. It is a
+// package-level function rather than a method on Drivers, because Go
+// doesn't allow a method to introduce type parameters beyond those of its
+// receiver. It returns an error if name isn't registered, or if the
+// registered driver doesn't implement InstanceOpener[T, R].
+//
+// OpenInstance applies every middleware registered via Drivers.Use to the
+// returned Repository, in registration order. It can't apply the
+// driver-side WrapDriver half of a middleware, because that's meant to
+// decorate Open, and OpenInstance bypasses Open entirely; it also has no
+// URL to select a subset of middlewares via the "middleware" query
+// parameter the way Open does, so all registered middlewares apply
+// unconditionally. Like Open, it reports OnOpen/OnOpenError to the
+// registry's Observer, if one is set via SetObserver.
+func OpenInstance[T any, D Driver[R], R Repository](ds *Drivers[D, R], name string, instance T, cfg interface{}) (R, error) {
+	start := time.Now()
+
+	driver, err := ds.DriverByName(name)
+	if err != nil {
+		var r R
+		ds.notifyOpenError(openInfo("", nil), time.Since(start), err)
+		return r, err
+	}
+
+	opener, ok := any(driver).(InstanceOpener[T, R])
+	if !ok {
+		var r R
+		err := fmt.Errorf("driver %q does not support opening from an existing instance", name)
+		ds.notifyOpenError(openInfo(name, nil), time.Since(start), err)
+		return r, err
+	}
+
+	repo, err := opener.OpenInstance(instance, cfg)
+	if err != nil {
+		ds.notifyOpenError(openInfo(name, nil), time.Since(start), err)
+		return repo, err
+	}
+
+	for _, mw := range ds.allMiddlewares() {
+		repo = mw.WrapRepository(repo)
+	}
+
+	ds.openedMu.Lock()
+	ds.opened = append(ds.opened, openedRepository[R]{driver: name, repo: repo})
+	ds.openedMu.Unlock()
+
+	ds.notifyOpen(openInfo(name, nil), time.Since(start))
+
+	return repo, nil
+}
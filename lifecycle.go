@@ -0,0 +1,379 @@
+package soforo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Initer is an optional interface that a Driver may implement to run
+// one-time initialization, such as warming a connection pool or validating
+// credentials, before the process starts serving traffic. Init is called
+// once per driver with the same provider value that would otherwise be
+// passed to Open.
+type Initer interface {
+	Init(provider interface{}) error
+}
+
+// PrerequisiteDriver is an optional interface that a Driver may implement to
+// declare other drivers, identified by their registered name, that must
+// finish initializing before this driver's Init is called.
+type PrerequisiteDriver interface {
+	Prerequisites() []string
+}
+
+// HealthChecker is an optional interface that a Repository may implement to
+// verify that its underlying connection is still usable.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// InitStatus describes the outcome of initializing a single driver.
+type InitStatus int
+
+const (
+	// InitOK means the driver implemented Initer and Init returned no error.
+	InitOK InitStatus = iota
+	// InitSkipped means the driver doesn't implement Initer, so there was
+	// nothing to run.
+	InitSkipped
+	// InitFailed means Init returned an error, or the driver could not be
+	// initialized because of an unknown, cyclic or failed prerequisite.
+	InitFailed
+)
+
+// String returns a human-readable name for the status.
+func (s InitStatus) String() string {
+	switch s {
+	case InitOK:
+		return "ok"
+	case InitSkipped:
+		return "skipped"
+	case InitFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// InitResult reports the outcome of initializing a single registered driver.
+type InitResult struct {
+	Name   string
+	Status InitStatus
+	Err    error
+}
+
+// Init runs the Init hook of every registered driver that implements Initer,
+// passing provider through unchanged. Drivers are initialized concurrently,
+// except that a driver's Init is only started once every driver named in its
+// Prerequisites has finished. A prerequisite that names an unregistered
+// driver, that takes part in a dependency cycle, or that was itself reported
+// as InitFailed, causes the affected driver(s) to be reported as InitFailed
+// without their Init being called, rather than aborting the whole batch;
+// unrelated drivers still run. A driver that actually sits on a
+// dependency cycle is reported as such; a driver that merely depends on one
+// (directly or transitively) is reported as blocked by that cycle instead,
+// so the two cases aren't conflated. Otherwise, drivers that don't implement
+// Initer are reported as InitSkipped. The returned results are sorted by
+// driver name.
+func (ds *Drivers[D, R]) Init(provider interface{}) []InitResult {
+	ds.mu.RLock()
+	names := make([]string, 0, len(ds.drivers))
+	drivers := make(map[string]D, len(ds.drivers))
+	for name, d := range ds.drivers {
+		names = append(names, name)
+		drivers[name] = d
+	}
+	ds.mu.RUnlock()
+	sort.Strings(names)
+
+	prereqs := make(map[string][]string, len(names))
+	for _, name := range names {
+		if pd, ok := any(drivers[name]).(PrerequisiteDriver); ok {
+			prereqs[name] = pd.Prerequisites()
+		}
+	}
+
+	order, cyclic := topoSortDrivers(names, prereqs)
+
+	var resMu sync.Mutex
+	results := make(map[string]*InitResult, len(names))
+	done := make(map[string]chan struct{}, len(names))
+	for _, name := range names {
+		done[name] = make(chan struct{})
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range order {
+		name := name
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[name])
+
+			for _, dep := range prereqs[name] {
+				ch, known := done[dep]
+				if !known {
+					resMu.Lock()
+					results[name] = &InitResult{Name: name, Status: InitFailed, Err: fmt.Errorf("unknown prerequisite %q", dep)}
+					resMu.Unlock()
+					return
+				}
+				<-ch
+
+				resMu.Lock()
+				depFailed := results[dep] != nil && results[dep].Status == InitFailed
+				resMu.Unlock()
+				if depFailed {
+					resMu.Lock()
+					results[name] = &InitResult{Name: name, Status: InitFailed, Err: fmt.Errorf("prerequisite %q failed to initialize", dep)}
+					resMu.Unlock()
+					return
+				}
+			}
+
+			initer, ok := any(drivers[name]).(Initer)
+			if !ok {
+				resMu.Lock()
+				results[name] = &InitResult{Name: name, Status: InitSkipped}
+				resMu.Unlock()
+				return
+			}
+
+			err := initer.Init(provider)
+			resMu.Lock()
+			if err != nil {
+				results[name] = &InitResult{Name: name, Status: InitFailed, Err: err}
+			} else {
+				results[name] = &InitResult{Name: name, Status: InitOK}
+			}
+			resMu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(cyclic) > 0 {
+		inCycle := cycleMembers(cyclic, prereqs)
+		for _, name := range cyclic {
+			if inCycle[name] {
+				results[name] = &InitResult{Name: name, Status: InitFailed, Err: fmt.Errorf("prerequisite cycle detected involving driver %q", name)}
+				continue
+			}
+			blocker := blockingCycleMember(name, prereqs, inCycle)
+			results[name] = &InitResult{Name: name, Status: InitFailed, Err: fmt.Errorf("blocked by prerequisite %q, which is part of a dependency cycle", blocker)}
+		}
+	}
+
+	out := make([]InitResult, 0, len(names))
+	for _, name := range names {
+		out = append(out, *results[name])
+	}
+	return out
+}
+
+// topoSortDrivers returns a dependency-respecting processing order for names
+// given their prerequisites. Any names that could not be ordered because they
+// take part in a prerequisite cycle (directly or transitively) are returned
+// in cyclic instead. Prerequisites that name an unregistered driver are
+// ignored here; Init reports those separately.
+func topoSortDrivers(names []string, prereqs map[string][]string) (order, cyclic []string) {
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+
+	indegree := make(map[string]int, len(names))
+	dependents := make(map[string][]string, len(names))
+	for _, name := range names {
+		for _, dep := range prereqs[name] {
+			if !known[dep] {
+				continue
+			}
+			indegree[name]++
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+
+	var queue []string
+	for _, name := range names {
+		if indegree[name] == 0 {
+			queue = append(queue, name)
+		}
+	}
+	sort.Strings(queue)
+
+	visited := make(map[string]bool, len(names))
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		visited[name] = true
+		order = append(order, name)
+
+		var ready []string
+		for _, dependent := range dependents[name] {
+			indegree[dependent]--
+			if indegree[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+		sort.Strings(ready)
+		queue = append(queue, ready...)
+	}
+
+	for _, name := range names {
+		if !visited[name] {
+			cyclic = append(cyclic, name)
+		}
+	}
+
+	return order, cyclic
+}
+
+// cycleMembers returns, among names, the ones that actually sit on a
+// prerequisite cycle, as opposed to merely depending (directly or
+// transitively) on one. It's a standard white/gray/black DFS: a back edge
+// to a gray (in-progress) node means every name still on the call stack
+// between that node and the current one is part of a cycle.
+func cycleMembers(names []string, prereqs map[string][]string) map[string]bool {
+	const (
+		white = iota
+		gray
+		black
+	)
+
+	color := make(map[string]int, len(names))
+	inCycle := make(map[string]bool)
+	var stack []string
+	stackPos := make(map[string]int, len(names))
+
+	var visit func(name string)
+	visit = func(name string) {
+		color[name] = gray
+		stackPos[name] = len(stack)
+		stack = append(stack, name)
+
+		for _, dep := range prereqs[name] {
+			switch color[dep] {
+			case white:
+				visit(dep)
+			case gray:
+				for i := stackPos[dep]; i < len(stack); i++ {
+					inCycle[stack[i]] = true
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		delete(stackPos, name)
+		color[name] = black
+	}
+
+	for _, name := range names {
+		if color[name] == white {
+			visit(name)
+		}
+	}
+
+	return inCycle
+}
+
+// blockingCycleMember finds a prerequisite of name, possibly several hops
+// away, that sits on an actual dependency cycle (per inCycle). name itself
+// is assumed not to be in inCycle; since it was still reported as unordered,
+// following its prerequisites must eventually reach one that is.
+func blockingCycleMember(name string, prereqs map[string][]string, inCycle map[string]bool) string {
+	seen := make(map[string]bool)
+
+	var find func(string) string
+	find = func(n string) string {
+		if seen[n] {
+			return ""
+		}
+		seen[n] = true
+
+		for _, dep := range prereqs[n] {
+			if inCycle[dep] {
+				return dep
+			}
+			if found := find(dep); found != "" {
+				return found
+			}
+		}
+		return ""
+	}
+
+	if blocker := find(name); blocker != "" {
+		return blocker
+	}
+	return name
+}
+
+// HealthResult reports the outcome of health-checking a single opened
+// repository.
+type HealthResult struct {
+	Driver string
+	Err    error
+}
+
+// Forget removes repo from the set of repositories Open/OpenInstance/
+// OpenShared have tracked for HealthCheck. Go's type system gives no
+// generic way for Open to intercept Close on an arbitrary R, so closing a
+// Repository doesn't automatically untrack it; long-running processes that
+// open and close many repositories over time should call Forget alongside
+// Close, to keep HealthCheck's fan-out, and this registry's memory use,
+// bounded. repo is matched by equality (Repository requires comparable);
+// Forget is a no-op if no match is found.
+func (ds *Drivers[D, R]) Forget(repo R) {
+	ds.untrackOpened(repo)
+}
+
+// untrackOpened removes the first tracked entry holding repo, if any.
+func (ds *Drivers[D, R]) untrackOpened(repo R) {
+	ds.openedMu.Lock()
+	defer ds.openedMu.Unlock()
+
+	for i, o := range ds.opened {
+		if any(o.repo) == any(repo) {
+			ds.opened = append(ds.opened[:i], ds.opened[i+1:]...)
+			return
+		}
+	}
+}
+
+// HealthCheck runs HealthCheck concurrently on every repository that has
+// been opened through this registry via Open, OpenInstance or OpenShared
+// (and not yet untracked) and implements HealthChecker, so that applications
+// can wire a single /healthz endpoint without reimplementing driver
+// enumeration themselves. Repositories that don't implement HealthChecker
+// are omitted from the result. ctx is passed to every HealthCheck call, and
+// HealthCheck returns once all of those calls have returned.
+func (ds *Drivers[D, R]) HealthCheck(ctx context.Context) []HealthResult {
+	ds.openedMu.RLock()
+	opened := make([]openedRepository[R], len(ds.opened))
+	copy(opened, ds.opened)
+	ds.openedMu.RUnlock()
+
+	var wg sync.WaitGroup
+	var resMu sync.Mutex
+	results := make([]HealthResult, 0, len(opened))
+	for _, o := range opened {
+		checker, ok := any(o.repo).(HealthChecker)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func(driver string, checker HealthChecker) {
+			defer wg.Done()
+			err := checker.HealthCheck(ctx)
+
+			resMu.Lock()
+			results = append(results, HealthResult{Driver: driver, Err: err})
+			resMu.Unlock()
+		}(o.driver, checker)
+	}
+	wg.Wait()
+
+	return results
+}
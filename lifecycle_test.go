@@ -0,0 +1,225 @@
+package soforo
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestTopoSortDriversOrdersByPrerequisite(t *testing.T) {
+	names := []string{"c", "a", "b"}
+	prereqs := map[string][]string{
+		"b": {"a"},
+		"c": {"b"},
+	}
+
+	order, cyclic := topoSortDrivers(names, prereqs)
+	if len(cyclic) != 0 {
+		t.Fatalf("expected no cyclic drivers, got %v", cyclic)
+	}
+
+	pos := make(map[string]int, len(order))
+	for i, name := range order {
+		pos[name] = i
+	}
+	if pos["a"] >= pos["b"] || pos["b"] >= pos["c"] {
+		t.Fatalf("expected order a, b, c, got %v", order)
+	}
+}
+
+func TestTopoSortDriversDetectsCycle(t *testing.T) {
+	names := []string{"a", "b", "c"}
+	prereqs := map[string][]string{
+		"a": {"b"},
+		"b": {"a"},
+	}
+
+	order, cyclic := topoSortDrivers(names, prereqs)
+
+	if len(order) != 1 || order[0] != "c" {
+		t.Fatalf("expected only c to be orderable, got %v", order)
+	}
+	sort.Strings(cyclic)
+	if len(cyclic) != 2 || cyclic[0] != "a" || cyclic[1] != "b" {
+		t.Fatalf("expected a and b reported as cyclic, got %v", cyclic)
+	}
+}
+
+func TestTopoSortDriversIgnoresUnknownPrerequisite(t *testing.T) {
+	names := []string{"a"}
+	prereqs := map[string][]string{
+		"a": {"missing"},
+	}
+
+	order, cyclic := topoSortDrivers(names, prereqs)
+	if len(cyclic) != 0 {
+		t.Fatalf("expected no cyclic drivers, got %v", cyclic)
+	}
+	if len(order) != 1 || order[0] != "a" {
+		t.Fatalf("expected a to be ordered despite its unknown prerequisite, got %v", order)
+	}
+}
+
+func TestCycleMembersDistinguishesCycleFromBlocked(t *testing.T) {
+	// a and b depend on each other (a real cycle); c merely depends on a,
+	// transitively, without being part of the cycle itself.
+	prereqs := map[string][]string{
+		"a": {"b"},
+		"b": {"a"},
+		"c": {"a"},
+	}
+	cyclic := []string{"a", "b", "c"}
+
+	inCycle := cycleMembers(cyclic, prereqs)
+
+	if !inCycle["a"] || !inCycle["b"] {
+		t.Fatalf("expected a and b to be reported as cycle members, got %v", inCycle)
+	}
+	if inCycle["c"] {
+		t.Fatalf("expected c to not be reported as a cycle member, it only depends on one")
+	}
+
+	if blocker := blockingCycleMember("c", prereqs, inCycle); blocker != "a" {
+		t.Fatalf("expected c to be reported as blocked by a, got %q", blocker)
+	}
+}
+
+type lifecycleTestDriver struct {
+	prereqs []string
+}
+
+func (d lifecycleTestDriver) Open(u *url.URL, provider interface{}) (*poolTestRepo, error) {
+	return &poolTestRepo{}, nil
+}
+
+func (d lifecycleTestDriver) Prerequisites() []string {
+	return d.prereqs
+}
+
+func TestInitReportsCycleAndBlockedDrivers(t *testing.T) {
+	ds := NewDrivers[lifecycleTestDriver, *poolTestRepo]("test")
+	ds.Register("a", lifecycleTestDriver{prereqs: []string{"b"}})
+	ds.Register("b", lifecycleTestDriver{prereqs: []string{"a"}})
+	ds.Register("c", lifecycleTestDriver{prereqs: []string{"a"}})
+
+	results := ds.Init(nil)
+
+	byName := make(map[string]InitResult, len(results))
+	for _, r := range results {
+		byName[r.Name] = r
+	}
+
+	for _, name := range []string{"a", "b", "c"} {
+		if byName[name].Status != InitFailed {
+			t.Fatalf("expected %s to be InitFailed, got %s", name, byName[name].Status)
+		}
+	}
+	if byName["c"].Err == nil {
+		t.Fatalf("expected c to report an error")
+	}
+}
+
+// orderTrackingDriver implements Initer and PrerequisiteDriver, recording
+// its name to a shared, mutex-guarded slice when Init runs, so a test can
+// assert that Init actually waited for prerequisites rather than merely
+// reporting InitOK for everything.
+type orderTrackingDriver struct {
+	name    string
+	prereqs []string
+	mu      *sync.Mutex
+	order   *[]string
+}
+
+func (d orderTrackingDriver) Open(u *url.URL, provider interface{}) (*poolTestRepo, error) {
+	return &poolTestRepo{}, nil
+}
+
+func (d orderTrackingDriver) Prerequisites() []string {
+	return d.prereqs
+}
+
+func (d orderTrackingDriver) Init(provider interface{}) error {
+	d.mu.Lock()
+	*d.order = append(*d.order, d.name)
+	d.mu.Unlock()
+	return nil
+}
+
+func TestInitRunsInPrerequisiteOrder(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+
+	ds := NewDrivers[orderTrackingDriver, *poolTestRepo]("test")
+	ds.Register("a", orderTrackingDriver{name: "a", mu: &mu, order: &order})
+	ds.Register("b", orderTrackingDriver{name: "b", prereqs: []string{"a"}, mu: &mu, order: &order})
+	ds.Register("c", orderTrackingDriver{name: "c", prereqs: []string{"b"}, mu: &mu, order: &order})
+
+	results := ds.Init(nil)
+	for _, r := range results {
+		if r.Status != InitOK {
+			t.Fatalf("expected %s to be InitOK, got %s (%v)", r.Name, r.Status, r.Err)
+		}
+	}
+
+	pos := make(map[string]int, len(order))
+	for i, name := range order {
+		pos[name] = i
+	}
+	if pos["a"] >= pos["b"] || pos["b"] >= pos["c"] {
+		t.Fatalf("expected Init to run a before b before c, got order %v", order)
+	}
+}
+
+type healthTestRepo struct {
+	err error
+}
+
+func (r *healthTestRepo) Close() error { return nil }
+
+func (r *healthTestRepo) HealthCheck(ctx context.Context) error {
+	return r.err
+}
+
+type healthTestDriver struct {
+	err error
+}
+
+func (d healthTestDriver) Open(u *url.URL, provider interface{}) (*healthTestRepo, error) {
+	return &healthTestRepo{err: d.err}, nil
+}
+
+func TestHealthCheckFansOutToOpenedRepositories(t *testing.T) {
+	wantErr := errors.New("boom")
+
+	ds := NewDrivers[healthTestDriver, *healthTestRepo]("test")
+	ds.Register("ok", healthTestDriver{})
+	ds.Register("bad", healthTestDriver{err: wantErr})
+
+	u1, _ := url.Parse("ok://host/db")
+	u2, _ := url.Parse("bad://host/db")
+	if _, err := ds.Open(u1, nil); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := ds.Open(u2, nil); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	results := ds.HealthCheck(context.Background())
+	if len(results) != 2 {
+		t.Fatalf("expected 2 health results, got %d", len(results))
+	}
+
+	byDriver := make(map[string]HealthResult, len(results))
+	for _, r := range results {
+		byDriver[r.Driver] = r
+	}
+	if byDriver["ok"].Err != nil {
+		t.Fatalf("expected ok driver to report healthy, got %v", byDriver["ok"].Err)
+	}
+	if byDriver["bad"].Err != wantErr {
+		t.Fatalf("expected bad driver to report %v, got %v", wantErr, byDriver["bad"].Err)
+	}
+}
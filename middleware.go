@@ -0,0 +1,90 @@
+package soforo
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Middleware decorates a Driver and/or the Repository it opens, so consumers
+// can layer cross-cutting concerns (metrics, tracing, retries, read-through
+// caching, circuit breaking, redacting credentials from errors, ...) without
+// every driver reimplementing them. Either method may simply return its
+// argument unchanged if a middleware only cares about one side.
+type Middleware[D Driver[R], R Repository] interface {
+	// WrapDriver returns a Driver that wraps d, typically to decorate the
+	// Open call itself (e.g. tracing the time it takes to connect).
+	WrapDriver(d D) D
+	// WrapRepository returns a Repository that wraps r, typically to
+	// decorate the methods the consuming package added to Repository.
+	WrapRepository(r R) R
+}
+
+// Use registers a middleware under name. Registered middlewares are not
+// applied by default; a connection opts into them by listing the names in
+// the "middleware" URL query parameter, e.g. "?middleware=metrics,retry".
+// When multiple middlewares are enabled for a connection, they are applied
+// in the order they were registered with Use, regardless of the order they
+// are listed in the query parameter. If Use is called twice with the same
+// name it panics.
+func (ds *Drivers[D, R]) Use(name string, mw Middleware[D, R]) {
+	ds.middlewareMu.Lock()
+	defer ds.middlewareMu.Unlock()
+
+	if _, dup := ds.middlewares[name]; dup {
+		panic(fmt.Sprintf("Use called twice for %s middleware %s", ds.name, name))
+	}
+	if ds.middlewares == nil {
+		ds.middlewares = make(map[string]Middleware[D, R])
+	}
+	ds.middlewares[name] = mw
+	ds.middlewareNames = append(ds.middlewareNames, name)
+}
+
+// enabledMiddlewares parses the "middleware" query parameter of u and
+// returns the matching middlewares in registration order. It returns an
+// error if the parameter names a middleware that hasn't been registered
+// with Use.
+func (ds *Drivers[D, R]) enabledMiddlewares(u *url.URL) ([]Middleware[D, R], error) {
+	raw := u.Query().Get("middleware")
+	if raw == "" {
+		return nil, nil
+	}
+
+	ds.middlewareMu.RLock()
+	defer ds.middlewareMu.RUnlock()
+
+	selected := make(map[string]bool)
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := ds.middlewares[name]; !ok {
+			return nil, fmt.Errorf("unknown middleware %q", name)
+		}
+		selected[name] = true
+	}
+
+	var mws []Middleware[D, R]
+	for _, name := range ds.middlewareNames {
+		if selected[name] {
+			mws = append(mws, ds.middlewares[name])
+		}
+	}
+	return mws, nil
+}
+
+// allMiddlewares returns every registered middleware in registration order.
+// It's used by paths like OpenInstance that have no URL to read a
+// "middleware" query parameter from, and so have no way to select a subset.
+func (ds *Drivers[D, R]) allMiddlewares() []Middleware[D, R] {
+	ds.middlewareMu.RLock()
+	defer ds.middlewareMu.RUnlock()
+
+	mws := make([]Middleware[D, R], 0, len(ds.middlewareNames))
+	for _, name := range ds.middlewareNames {
+		mws = append(mws, ds.middlewares[name])
+	}
+	return mws
+}
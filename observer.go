@@ -0,0 +1,143 @@
+package soforo
+
+import (
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+// Observer receives notifications about a Drivers registry's activity, so
+// operators can see which drivers are in use and their failure modes
+// without every consumer package instrumenting Open individually. All
+// methods must be safe for concurrent use.
+type Observer interface {
+	// OnRegister is called after a driver is successfully registered via
+	// Register or Replace.
+	OnRegister(name string)
+	// OnOpen is called after Open, OpenInstance or OpenShared successfully
+	// opens a Repository, with the time the call took.
+	OnOpen(info OpenInfo, d time.Duration)
+	// OnOpenError is called when Open, OpenInstance or OpenShared fails,
+	// with the time spent before failing. info.Driver is empty if the URL
+	// couldn't be resolved to a driver at all.
+	OnOpenError(info OpenInfo, d time.Duration, err error)
+	// OnClose is called after a Repository opened through OpenShared is
+	// closed by the pool, either because its last reference was released
+	// past an idle/lifetime limit, or because it was evicted. Repositories
+	// closed directly by the caller via Open don't trigger this hook, since
+	// Drivers never wraps the Repository values Open returns.
+	OnClose(scheme string)
+}
+
+// OpenInfo describes the driver and, where one was involved, the URL that
+// Open, OpenInstance or OpenShared opened or failed to open. Host and User
+// are both empty for OpenInstance, which opens from an existing instance
+// rather than a URL. User never carries a password: it's the URL's
+// username alone, or "username:xxxxx" if the URL also set a password, so
+// Observer implementations don't have to redact credentials themselves.
+type OpenInfo struct {
+	Driver string
+	Host   string
+	User   string
+}
+
+// openInfo builds the OpenInfo passed to OnOpen/OnOpenError for a URL-based
+// open. u is nil for OpenInstance, which has no URL.
+func openInfo(driver string, u *url.URL) OpenInfo {
+	info := OpenInfo{Driver: driver}
+	if u == nil {
+		return info
+	}
+
+	info.Host = u.Host
+	if u.User != nil {
+		info.User = u.User.Username()
+		if _, ok := u.User.Password(); ok {
+			info.User += ":xxxxx"
+		}
+	}
+	return info
+}
+
+// SetObserver registers o to receive notifications about this registry's
+// activity. Passing nil disables notification. There is only one Observer
+// at a time; wrap multiple observers in one that fans out to each if more
+// than one is needed.
+func (ds *Drivers[D, R]) SetObserver(o Observer) {
+	ds.observerMu.Lock()
+	ds.observer = o
+	ds.observerMu.Unlock()
+}
+
+func (ds *Drivers[D, R]) notifyRegister(name string) {
+	ds.observerMu.RLock()
+	o := ds.observer
+	ds.observerMu.RUnlock()
+
+	if o != nil {
+		o.OnRegister(name)
+	}
+}
+
+func (ds *Drivers[D, R]) notifyOpen(info OpenInfo, d time.Duration) {
+	ds.observerMu.RLock()
+	o := ds.observer
+	ds.observerMu.RUnlock()
+
+	if o != nil {
+		o.OnOpen(info, d)
+	}
+}
+
+func (ds *Drivers[D, R]) notifyOpenError(info OpenInfo, d time.Duration, err error) {
+	ds.observerMu.RLock()
+	o := ds.observer
+	ds.observerMu.RUnlock()
+
+	if o != nil {
+		o.OnOpenError(info, d, err)
+	}
+}
+
+func (ds *Drivers[D, R]) notifyClose(scheme string) {
+	ds.observerMu.RLock()
+	o := ds.observer
+	ds.observerMu.RUnlock()
+
+	if o != nil {
+		o.OnClose(scheme)
+	}
+}
+
+// SlogObserver is an Observer that writes each notification as a structured
+// log record to a *slog.Logger. It requires no dependency beyond the
+// standard library; adapters for metrics or tracing systems can be built the
+// same way against the Observer interface.
+type SlogObserver struct {
+	Logger *slog.Logger
+}
+
+// NewSlogObserver returns a SlogObserver that logs to logger.
+func NewSlogObserver(logger *slog.Logger) *SlogObserver {
+	return &SlogObserver{Logger: logger}
+}
+
+// OnRegister implements Observer.
+func (o *SlogObserver) OnRegister(name string) {
+	o.Logger.Info("driver registered", "driver", name)
+}
+
+// OnOpen implements Observer.
+func (o *SlogObserver) OnOpen(info OpenInfo, d time.Duration) {
+	o.Logger.Info("repository opened", "driver", info.Driver, "host", info.Host, "duration", d)
+}
+
+// OnOpenError implements Observer.
+func (o *SlogObserver) OnOpenError(info OpenInfo, d time.Duration, err error) {
+	o.Logger.Error("failed to open repository", "driver", info.Driver, "host", info.Host, "duration", d, "error", err)
+}
+
+// OnClose implements Observer.
+func (o *SlogObserver) OnClose(scheme string) {
+	o.Logger.Info("pooled repository closed", "driver", scheme)
+}
@@ -0,0 +1,84 @@
+// Package otelobserver adapts soforo.Observer to OpenTelemetry tracing. It
+// depends on go.opentelemetry.io/otel, which the core soforo package
+// doesn't; add it to your module only if you use this adapter.
+package otelobserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/amicolabs/soforo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Observer is a soforo.Observer that records a span for each Open/
+// OpenInstance/OpenShared call, tagged with the driver, host and a
+// redacted userinfo.
+//
+// soforo.Observer's OnOpen/OnOpenError hooks fire after the call has
+// already finished, so this adapter can't wrap a span directly around
+// Open; it records the span retroactively instead, using
+// trace.WithTimestamp to back-date it to when the call actually started.
+type Observer struct {
+	tracer trace.Tracer
+}
+
+// NewObserver returns an Observer that records spans using the named
+// tracer from the global otel TracerProvider.
+func NewObserver(tracerName string) *Observer {
+	return &Observer{tracer: otel.Tracer(tracerName)}
+}
+
+// OnRegister implements soforo.Observer. Driver registration isn't traced
+// by this adapter.
+func (o *Observer) OnRegister(name string) {}
+
+// OnOpen implements soforo.Observer.
+func (o *Observer) OnOpen(info soforo.OpenInfo, d time.Duration) {
+	o.recordSpan(info, d, nil)
+}
+
+// OnOpenError implements soforo.Observer.
+func (o *Observer) OnOpenError(info soforo.OpenInfo, d time.Duration, err error) {
+	o.recordSpan(info, d, err)
+}
+
+// OnClose implements soforo.Observer.
+func (o *Observer) OnClose(scheme string) {
+	_, span := o.tracer.Start(context.Background(), "soforo.Close",
+		trace.WithAttributes(attribute.String("soforo.driver", scheme)))
+	span.End()
+}
+
+// recordSpan starts and immediately ends a span representing an Open call
+// that took d and either succeeded (err == nil) or failed with err. host
+// and user attributes are omitted when empty, which is always the case for
+// OpenInstance, since it has no URL.
+func (o *Observer) recordSpan(info soforo.OpenInfo, d time.Duration, err error) {
+	end := time.Now()
+
+	attrs := []attribute.KeyValue{attribute.String("soforo.driver", info.Driver)}
+	if info.Host != "" {
+		attrs = append(attrs, attribute.String("soforo.host", info.Host))
+	}
+	if info.User != "" {
+		attrs = append(attrs, attribute.String("soforo.user", info.User))
+	}
+
+	_, span := o.tracer.Start(context.Background(), "soforo.Open",
+		trace.WithTimestamp(end.Add(-d)),
+		trace.WithAttributes(attrs...),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetStatus(codes.Ok, "")
+	}
+	span.End(trace.WithTimestamp(end))
+}
+
+var _ soforo.Observer = (*Observer)(nil)
@@ -0,0 +1,254 @@
+package soforo
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PoolOptions bounds how long OpenShared keeps an idle, ref-counted
+// Repository around so it can be handed out again instead of being closed
+// and reopened. The zero value keeps the base ref-counting contract exactly:
+// a repository is closed as soon as its last ReleaseFunc is called, with no
+// idle retention at all.
+type PoolOptions struct {
+	// IdleTimeout is how long a repository with no active callers is kept
+	// around for reuse before being closed. Zero means a repository is
+	// closed immediately once it has no active callers, rather than being
+	// retained.
+	IdleTimeout time.Duration
+	// MaxIdle caps the number of repositories a scheme keeps idle for reuse
+	// at once; it only has an effect when IdleTimeout also retains idle
+	// repositories. Once exceeded, the longest-idle repositories are closed
+	// first, ahead of their IdleTimeout. Zero means unlimited.
+	MaxIdle int
+	// MaxLifetime closes a repository once it has existed for at least this
+	// long, the next time it has no active callers. Zero disables this.
+	MaxLifetime time.Duration
+}
+
+// ReleaseFunc releases a handle obtained from OpenShared. The underlying
+// Repository is only closed once every handle sharing it has been released.
+// Calling a ReleaseFunc more than once has no additional effect.
+type ReleaseFunc func()
+
+// pooledRepository is a ref-counted Repository shared by OpenShared callers
+// that resolved to the same canonical pool key.
+type pooledRepository[R Repository] struct {
+	mu        sync.Mutex
+	key       string
+	repo      R
+	refs      int
+	scheme    string
+	openedAt  time.Time
+	idleSince time.Time
+}
+
+// SetPoolOptions sets the PoolOptions used by OpenShared for schemes that
+// don't have an override set via SetPoolOptionsForScheme.
+func (ds *Drivers[D, R]) SetPoolOptions(opts PoolOptions) {
+	ds.poolMu.Lock()
+	defer ds.poolMu.Unlock()
+
+	ds.poolOpts = opts
+}
+
+// SetPoolOptionsForScheme overrides the PoolOptions used by OpenShared for
+// the given URL scheme.
+func (ds *Drivers[D, R]) SetPoolOptionsForScheme(scheme string, opts PoolOptions) {
+	ds.poolMu.Lock()
+	defer ds.poolMu.Unlock()
+
+	if ds.poolOptsByScheme == nil {
+		ds.poolOptsByScheme = make(map[string]PoolOptions)
+	}
+	ds.poolOptsByScheme[scheme] = opts
+}
+
+// OpenShared behaves like Open, except that concurrent callers resolving to
+// the same canonicalized URL (sorted query parameters, lowercased scheme and
+// host) share the same underlying Repository. The Repository is ref-counted
+// and eligible for reuse by later OpenShared calls until every ReleaseFunc
+// handed out for it has been called, at which point it is closed — unless
+// PoolOptions.IdleTimeout has been set for its scheme, in which case it is
+// instead kept idle for reuse until that timeout (or MaxIdle/MaxLifetime)
+// evicts it. Callers must call the returned ReleaseFunc exactly once when
+// done, instead of calling Close on the Repository directly.
+func (ds *Drivers[D, R]) OpenShared(u *url.URL, provider interface{}) (R, ReleaseFunc, error) {
+	key := poolKey(u)
+
+	ds.poolMu.Lock()
+	closed := ds.evictLocked()
+	if entry, ok := ds.pool[key]; ok {
+		entry.mu.Lock()
+		entry.refs++
+		entry.mu.Unlock()
+		ds.poolMu.Unlock()
+		ds.notifyCloses(closed)
+		return entry.repo, ds.releaseFunc(entry), nil
+	}
+	ds.poolMu.Unlock()
+	ds.notifyCloses(closed)
+
+	repo, err := ds.Open(u, provider)
+	if err != nil {
+		var r R
+		return r, nil, err
+	}
+
+	entry := &pooledRepository[R]{key: key, repo: repo, refs: 1, scheme: u.Scheme, openedAt: time.Now()}
+
+	ds.poolMu.Lock()
+	if existing, ok := ds.pool[key]; ok {
+		// Lost the race to a concurrent OpenShared for the same key; keep
+		// theirs and close the one this call just opened.
+		existing.mu.Lock()
+		existing.refs++
+		existing.mu.Unlock()
+		ds.poolMu.Unlock()
+		_ = repo.Close()
+		ds.untrackOpened(repo)
+		return existing.repo, ds.releaseFunc(existing), nil
+	}
+	if ds.pool == nil {
+		ds.pool = make(map[string]*pooledRepository[R])
+	}
+	ds.pool[key] = entry
+	ds.poolMu.Unlock()
+
+	return repo, ds.releaseFunc(entry), nil
+}
+
+// releaseFunc returns the ReleaseFunc for entry, making the decrement
+// idempotent so a caller accidentally calling it twice doesn't under-count
+// other callers' references.
+func (ds *Drivers[D, R]) releaseFunc(entry *pooledRepository[R]) ReleaseFunc {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			// The decrement must happen under ds.poolMu, not just entry.mu:
+			// OpenShared only ever re-acquires a pooled entry (bumping refs)
+			// while holding ds.poolMu, so deciding whether refs dropped to
+			// zero under the same lock is what rules out a concurrent
+			// OpenShared re-acquiring this entry in the gap between the
+			// decrement and the close below.
+			ds.poolMu.Lock()
+
+			entry.mu.Lock()
+			entry.refs--
+			idle := entry.refs <= 0
+			if idle {
+				entry.idleSince = time.Now()
+			}
+			entry.mu.Unlock()
+
+			var closedNow string
+			if idle && ds.poolOptsForScheme(entry.scheme).IdleTimeout <= 0 {
+				if current, ok := ds.pool[entry.key]; ok && current == entry {
+					_ = entry.repo.Close()
+					delete(ds.pool, entry.key)
+					closedNow = entry.scheme
+				}
+			}
+			closed := ds.evictLocked()
+			ds.poolMu.Unlock()
+
+			if closedNow != "" {
+				ds.untrackOpened(entry.repo)
+				ds.notifyClose(closedNow)
+			}
+			ds.notifyCloses(closed)
+		})
+	}
+}
+
+// poolOptsForScheme returns the PoolOptions in effect for scheme. ds.poolMu
+// must be held.
+func (ds *Drivers[D, R]) poolOptsForScheme(scheme string) PoolOptions {
+	if opts, ok := ds.poolOptsByScheme[scheme]; ok {
+		return opts
+	}
+	return ds.poolOpts
+}
+
+// evictLocked closes and removes pool entries that have no active callers
+// and have either exceeded their scheme's IdleTimeout/MaxLifetime, or are
+// beyond the oldest allowed by MaxIdle. ds.poolMu must be held. It returns
+// the scheme of each entry it closed, so the caller can notify the Observer
+// after releasing ds.poolMu.
+func (ds *Drivers[D, R]) evictLocked() []string {
+	now := time.Now()
+	idleByScheme := make(map[string][]string)
+	var closed []string
+
+	for key, entry := range ds.pool {
+		entry.mu.Lock()
+		idle := entry.refs <= 0
+		idleSince, openedAt, scheme := entry.idleSince, entry.openedAt, entry.scheme
+		entry.mu.Unlock()
+
+		if !idle {
+			continue
+		}
+
+		opts := ds.poolOptsForScheme(scheme)
+		if (opts.IdleTimeout > 0 && now.Sub(idleSince) >= opts.IdleTimeout) ||
+			(opts.MaxLifetime > 0 && now.Sub(openedAt) >= opts.MaxLifetime) {
+			_ = entry.repo.Close()
+			delete(ds.pool, key)
+			ds.untrackOpened(entry.repo)
+			closed = append(closed, scheme)
+			continue
+		}
+
+		idleByScheme[scheme] = append(idleByScheme[scheme], key)
+	}
+
+	for scheme, keys := range idleByScheme {
+		opts := ds.poolOptsForScheme(scheme)
+		if opts.MaxIdle <= 0 || len(keys) <= opts.MaxIdle {
+			continue
+		}
+
+		sort.Slice(keys, func(i, j int) bool {
+			return ds.pool[keys[i]].idleSince.Before(ds.pool[keys[j]].idleSince)
+		})
+		for _, key := range keys[:len(keys)-opts.MaxIdle] {
+			entry := ds.pool[key]
+			_ = entry.repo.Close()
+			delete(ds.pool, key)
+			ds.untrackOpened(entry.repo)
+			closed = append(closed, scheme)
+		}
+	}
+
+	return closed
+}
+
+// notifyCloses calls notifyClose for each scheme in closed. It must be
+// called without ds.poolMu held.
+func (ds *Drivers[D, R]) notifyCloses(closed []string) {
+	for _, scheme := range closed {
+		ds.notifyClose(scheme)
+	}
+}
+
+// poolKey canonicalizes u into a stable pool key: the scheme and host are
+// lowercased, and query parameters (and their values) are sorted, so that
+// equivalent URLs written differently resolve to the same shared
+// Repository.
+func poolKey(u *url.URL) string {
+	canon := *u
+	canon.Scheme = strings.ToLower(u.Scheme)
+	canon.Host = strings.ToLower(u.Host)
+
+	q := canon.Query()
+	for _, values := range q {
+		sort.Strings(values)
+	}
+	canon.RawQuery = q.Encode()
+
+	return canon.String()
+}
@@ -0,0 +1,167 @@
+package soforo
+
+import (
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type poolTestRepo struct {
+	closed int32
+}
+
+func (r *poolTestRepo) Close() error {
+	atomic.StoreInt32(&r.closed, 1)
+	return nil
+}
+
+type poolTestDriver struct {
+	opens *int32
+}
+
+func (d poolTestDriver) Open(u *url.URL, provider interface{}) (*poolTestRepo, error) {
+	atomic.AddInt32(d.opens, 1)
+	return &poolTestRepo{}, nil
+}
+
+func TestOpenSharedRefCounting(t *testing.T) {
+	var opens int32
+	ds := NewDrivers[poolTestDriver, *poolTestRepo]("test")
+	ds.Register("mem", poolTestDriver{opens: &opens})
+
+	u, _ := url.Parse("mem://host/db")
+
+	repo1, release1, err := ds.OpenShared(u, nil)
+	if err != nil {
+		t.Fatalf("OpenShared: %v", err)
+	}
+	repo2, release2, err := ds.OpenShared(u, nil)
+	if err != nil {
+		t.Fatalf("OpenShared: %v", err)
+	}
+
+	if repo1 != repo2 {
+		t.Fatalf("expected the same shared repository for concurrent callers, got different instances")
+	}
+	if got := atomic.LoadInt32(&opens); got != 1 {
+		t.Fatalf("expected exactly 1 underlying Open call, got %d", got)
+	}
+
+	release1()
+	if atomic.LoadInt32(&repo1.closed) != 0 {
+		t.Fatalf("repository closed after releasing only one of two handles")
+	}
+
+	release2()
+	if atomic.LoadInt32(&repo1.closed) != 1 {
+		t.Fatalf("repository not closed after releasing the last handle")
+	}
+
+	// A ReleaseFunc is idempotent; calling it again must not panic or
+	// double-close.
+	release2()
+}
+
+func TestOpenSharedIdleTimeoutEviction(t *testing.T) {
+	var opens int32
+	ds := NewDrivers[poolTestDriver, *poolTestRepo]("test")
+	ds.Register("mem", poolTestDriver{opens: &opens})
+	ds.SetPoolOptions(PoolOptions{IdleTimeout: time.Millisecond})
+
+	u, _ := url.Parse("mem://host/db")
+
+	repo1, release1, err := ds.OpenShared(u, nil)
+	if err != nil {
+		t.Fatalf("OpenShared: %v", err)
+	}
+	release1()
+
+	time.Sleep(5 * time.Millisecond)
+
+	repo2, release2, err := ds.OpenShared(u, nil)
+	if err != nil {
+		t.Fatalf("OpenShared: %v", err)
+	}
+	release2()
+
+	if repo1 == repo2 {
+		t.Fatalf("expected the idle-expired repository to be replaced by a new one")
+	}
+	if atomic.LoadInt32(&repo1.closed) != 1 {
+		t.Fatalf("expected the idle-expired repository to have been closed")
+	}
+	if got := atomic.LoadInt32(&opens); got != 2 {
+		t.Fatalf("expected 2 underlying Open calls, got %d", got)
+	}
+}
+
+// TestOpenSharedConcurrentReleaseAndReacquire guards against a TOCTOU race
+// where a ReleaseFunc closes a repository that a concurrent OpenShared call
+// already re-acquired in the window between the refcount hitting zero and
+// the eviction decision. It runs many overlapping OpenShared/release pairs
+// and asserts a caller is never handed an already-closed repository.
+func TestOpenSharedConcurrentReleaseAndReacquire(t *testing.T) {
+	var opens int32
+	ds := NewDrivers[poolTestDriver, *poolTestRepo]("test")
+	ds.Register("mem", poolTestDriver{opens: &opens})
+
+	u, _ := url.Parse("mem://host/db")
+
+	const iterations = 2000
+	var wg sync.WaitGroup
+	errs := make(chan string, iterations)
+
+	for i := 0; i < iterations; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			repo, release, err := ds.OpenShared(u, nil)
+			if err != nil {
+				errs <- err.Error()
+				return
+			}
+			if atomic.LoadInt32(&repo.closed) != 0 {
+				errs <- "OpenShared returned an already-closed repository"
+				return
+			}
+			release()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for msg := range errs {
+		t.Fatal(msg)
+	}
+}
+
+func TestOpenSharedMaxIdleEviction(t *testing.T) {
+	var opens int32
+	ds := NewDrivers[poolTestDriver, *poolTestRepo]("test")
+	ds.Register("mem", poolTestDriver{opens: &opens})
+	ds.SetPoolOptions(PoolOptions{IdleTimeout: time.Hour, MaxIdle: 1})
+
+	u1, _ := url.Parse("mem://host/a")
+	u2, _ := url.Parse("mem://host/b")
+
+	repo1, release1, err := ds.OpenShared(u1, nil)
+	if err != nil {
+		t.Fatalf("OpenShared: %v", err)
+	}
+	release1()
+
+	repo2, release2, err := ds.OpenShared(u2, nil)
+	if err != nil {
+		t.Fatalf("OpenShared: %v", err)
+	}
+	release2()
+
+	if atomic.LoadInt32(&repo1.closed) != 1 {
+		t.Fatalf("expected the older idle repository to be evicted once MaxIdle was exceeded")
+	}
+	if atomic.LoadInt32(&repo2.closed) != 0 {
+		t.Fatalf("the newer repository should still be pooled, not evicted")
+	}
+}
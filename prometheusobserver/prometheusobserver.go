@@ -0,0 +1,70 @@
+// Package prometheusobserver adapts soforo.Observer to Prometheus metrics.
+// It depends on github.com/prometheus/client_golang, which the core soforo
+// package doesn't; add it to your module only if you use this adapter.
+package prometheusobserver
+
+import (
+	"time"
+
+	"github.com/amicolabs/soforo"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Observer is a soforo.Observer that records driver activity as Prometheus
+// metrics: a count of opens, a histogram of open latency, and a count of
+// open errors, each labeled by driver scheme.
+type Observer struct {
+	opens       *prometheus.CounterVec
+	openLatency *prometheus.HistogramVec
+	openErrors  *prometheus.CounterVec
+}
+
+// NewObserver creates the metrics this Observer records and registers them
+// with reg under the "soforo_" prefix.
+func NewObserver(reg prometheus.Registerer) (*Observer, error) {
+	o := &Observer{
+		opens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "soforo_open_total",
+			Help: "Total number of successful Open/OpenInstance/OpenShared calls, by driver.",
+		}, []string{"driver"}),
+		openLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "soforo_open_duration_seconds",
+			Help:    "Time taken by successful Open/OpenInstance/OpenShared calls, by driver.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"driver"}),
+		openErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "soforo_open_errors_total",
+			Help: "Total number of failed Open/OpenInstance/OpenShared calls, by driver.",
+		}, []string{"driver"}),
+	}
+
+	for _, c := range []prometheus.Collector{o.opens, o.openLatency, o.openErrors} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return o, nil
+}
+
+// OnRegister implements soforo.Observer. Driver registration isn't exposed
+// as a metric by this adapter.
+func (o *Observer) OnRegister(name string) {}
+
+// OnOpen implements soforo.Observer. It labels only by driver, not host, to
+// avoid unbounded label cardinality from per-connection hosts.
+func (o *Observer) OnOpen(info soforo.OpenInfo, d time.Duration) {
+	o.opens.WithLabelValues(info.Driver).Inc()
+	o.openLatency.WithLabelValues(info.Driver).Observe(d.Seconds())
+}
+
+// OnOpenError implements soforo.Observer.
+func (o *Observer) OnOpenError(info soforo.OpenInfo, d time.Duration, err error) {
+	o.openErrors.WithLabelValues(info.Driver).Inc()
+}
+
+// OnClose implements soforo.Observer. Pooled-repository closes aren't
+// exposed as a metric by this adapter.
+func (o *Observer) OnClose(scheme string) {}
+
+var _ soforo.Observer = (*Observer)(nil)
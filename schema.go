@@ -0,0 +1,154 @@
+package soforo
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ParamType describes the expected type of a URL query parameter declared
+// by a driver's SchemaProvider.
+type ParamType int
+
+const (
+	// ParamString accepts any value.
+	ParamString ParamType = iota
+	// ParamInt requires a value parseable by strconv.Atoi.
+	ParamInt
+	// ParamBool requires a value parseable by strconv.ParseBool.
+	ParamBool
+	// ParamDuration requires a value parseable by time.ParseDuration.
+	ParamDuration
+)
+
+// String returns a human-readable name for the type.
+func (t ParamType) String() string {
+	switch t {
+	case ParamString:
+		return "string"
+	case ParamInt:
+		return "int"
+	case ParamBool:
+		return "bool"
+	case ParamDuration:
+		return "duration"
+	default:
+		return "unknown"
+	}
+}
+
+// ParamSchema describes a single URL query parameter a driver accepts.
+type ParamSchema struct {
+	Name        string
+	Type        ParamType
+	Required    bool
+	Default     string
+	Description string
+}
+
+// DriverSchema describes the URL query parameters a driver accepts.
+type DriverSchema struct {
+	Driver string
+	Params []ParamSchema
+}
+
+// SchemaProvider is an optional interface a Driver may implement to declare
+// which URL query parameters it accepts. When a driver implements it,
+// Drivers.Validate uses the schema to catch typos and missing required
+// parameters before Open is called, and Drivers.Describe exposes it so
+// CLIs, config validators and doc generators can enumerate accepted
+// parameters without hardcoding them per driver.
+type SchemaProvider interface {
+	Schema() DriverSchema
+}
+
+// Validate checks the query parameters of u against the schema declared by
+// the driver matching u's scheme, if that driver implements SchemaProvider.
+// It returns an error for unknown parameters, missing required parameters,
+// or values that don't match their declared ParamType. Drivers that don't
+// implement SchemaProvider are not validated. The "middleware" parameter
+// recognized by Open is always allowed and isn't validated against the
+// driver's schema.
+func (ds *Drivers[D, R]) Validate(u *url.URL) error {
+	driver, err := ds.Driver(u)
+	if err != nil {
+		return err
+	}
+
+	provider, ok := any(driver).(SchemaProvider)
+	if !ok {
+		return nil
+	}
+	schema := provider.Schema()
+
+	byName := make(map[string]ParamSchema, len(schema.Params))
+	for _, p := range schema.Params {
+		byName[p.Name] = p
+	}
+
+	q := u.Query()
+	for name, values := range q {
+		if name == "middleware" {
+			continue
+		}
+
+		p, known := byName[name]
+		if !known {
+			return fmt.Errorf("unknown parameter %q for driver %q", name, u.Scheme)
+		}
+		for _, v := range values {
+			if err := validateParamValue(p, v); err != nil {
+				return fmt.Errorf("parameter %q for driver %q: %w", name, u.Scheme, err)
+			}
+		}
+	}
+
+	for _, p := range schema.Params {
+		if p.Required && q.Get(p.Name) == "" {
+			return fmt.Errorf("missing required parameter %q for driver %q", p.Name, u.Scheme)
+		}
+	}
+
+	return nil
+}
+
+// validateParamValue checks a single query parameter value against its
+// declared type.
+func validateParamValue(p ParamSchema, v string) error {
+	switch p.Type {
+	case ParamInt:
+		if _, err := strconv.Atoi(v); err != nil {
+			return fmt.Errorf("expected an int, got %q", v)
+		}
+	case ParamBool:
+		if _, err := strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("expected a bool, got %q", v)
+		}
+	case ParamDuration:
+		if _, err := time.ParseDuration(v); err != nil {
+			return fmt.Errorf("expected a duration, got %q", v)
+		}
+	case ParamString:
+		// Any value is valid.
+	}
+	return nil
+}
+
+// Describe returns the DriverSchema declared by the driver registered under
+// name. If the driver doesn't implement SchemaProvider, it returns a
+// DriverSchema with no Params. It returns an error if name isn't
+// registered.
+func (ds *Drivers[D, R]) Describe(name string) (DriverSchema, error) {
+	driver, err := ds.DriverByName(name)
+	if err != nil {
+		return DriverSchema{}, err
+	}
+
+	provider, ok := any(driver).(SchemaProvider)
+	if !ok {
+		return DriverSchema{Driver: name}, nil
+	}
+
+	return provider.Schema(), nil
+}
@@ -7,16 +7,20 @@ package soforo
 
 import (
 	"fmt"
-	"log"
 	"net/url"
 	"sort"
 	"sync"
+	"time"
 )
 
 // Repository the value that eventually ends up in the application. This
 // interface should be extended by the consuming package to include the methods
-// that the repository provides.
+// that the repository provides. Repository must be comparable, because Open,
+// OpenInstance and OpenShared track the repositories they hand out by
+// identity (for HealthCheck and Forget); in practice this means
+// implementations are pointer types, as is already the common case.
 type Repository interface {
+	comparable
 	Close() error
 }
 
@@ -44,6 +48,30 @@ type Drivers[D Driver[R], R Repository] struct {
 	name    string
 	drivers map[string]D
 	mu      sync.RWMutex
+
+	openedMu sync.RWMutex
+	opened   []openedRepository[R]
+
+	middlewareMu    sync.RWMutex
+	middlewareNames []string
+	middlewares     map[string]Middleware[D, R]
+
+	poolMu           sync.Mutex
+	pool             map[string]*pooledRepository[R]
+	poolOpts         PoolOptions
+	poolOptsByScheme map[string]PoolOptions
+
+	observerMu sync.RWMutex
+	observer   Observer
+}
+
+// openedRepository tracks a Repository returned by Open or OpenInstance
+// (OpenShared opens through Open) together with the
+// name of the driver that produced it, so it can later be enumerated by
+// Drivers.HealthCheck.
+type openedRepository[R Repository] struct {
+	driver string
+	repo   R
 }
 
 // NewDrivers returns a new Drivers collection.
@@ -58,12 +86,35 @@ func NewDrivers[D Driver[R], R Repository](name string) *Drivers[D, R] {
 // If Register is called twice with the same name it panics.
 func (ds *Drivers[D, R]) Register(name string, driver D) {
 	ds.mu.Lock()
-	defer ds.mu.Unlock()
-
 	if _, dup := ds.drivers[name]; dup {
-		log.Panicf("Register called twice for %s driver %s", ds.name, name)
+		ds.mu.Unlock()
+		panic(fmt.Sprintf("Register called twice for %s driver %s", ds.name, name))
 	}
 	ds.drivers[name] = driver
+	ds.mu.Unlock()
+
+	ds.notifyRegister(name)
+}
+
+// Unregister removes the driver registered under name, if any, so that name
+// becomes available again. This mirrors the unregisterAllDrivers pattern in
+// database/sql and exists primarily for test isolation.
+func (ds *Drivers[D, R]) Unregister(name string) {
+	ds.mu.Lock()
+	delete(ds.drivers, name)
+	ds.mu.Unlock()
+}
+
+// Replace atomically unregisters any existing driver under name and
+// registers driver in its place, bypassing the duplicate-registration panic
+// in Register. Like Unregister, it exists primarily for test isolation, so
+// a test can swap in a fake driver without caring whether the production
+// driver has already been registered.
+func (ds *Drivers[D, R]) Replace(name string, driver D) {
+	ds.mu.Lock()
+	ds.drivers[name] = driver
+	ds.mu.Unlock()
+	ds.notifyRegister(name)
 }
 
 // Drivers returns a sorted list of the names of the registered drivers.
@@ -113,12 +164,54 @@ func (ds *Drivers[D, R]) DriverByName(name string) (D, error) {
 // provide the driver with additional context. The driver must verify that
 // the provided instance is of the expected type and return an error if it is
 // not.
+//
+// Open tracks the returned Repository so HealthCheck can fan out to it;
+// callers that open and close many repositories over the life of a
+// long-running process should also call Forget once they've called Close,
+// to keep that tracking, and HealthCheck's fan-out, bounded.
 func (ds *Drivers[D, R]) Open(u *url.URL, provider interface{}) (R, error) {
+	start := time.Now()
+
 	driver, err := ds.Driver(u)
 	if err != nil {
 		var r R
+		ds.notifyOpenError(openInfo("", u), time.Since(start), err)
 		return r, err
 	}
 
-	return driver.Open(u, provider)
+	mws, err := ds.enabledMiddlewares(u)
+	if err != nil {
+		var r R
+		ds.notifyOpenError(openInfo(u.Scheme, u), time.Since(start), err)
+		return r, err
+	}
+
+	if q := u.Query(); q.Has("middleware") {
+		stripped := *u
+		q.Del("middleware")
+		stripped.RawQuery = q.Encode()
+		u = &stripped
+	}
+
+	for _, mw := range mws {
+		driver = mw.WrapDriver(driver)
+	}
+
+	repo, err := driver.Open(u, provider)
+	if err != nil {
+		ds.notifyOpenError(openInfo(u.Scheme, u), time.Since(start), err)
+		return repo, err
+	}
+
+	for _, mw := range mws {
+		repo = mw.WrapRepository(repo)
+	}
+
+	ds.openedMu.Lock()
+	ds.opened = append(ds.opened, openedRepository[R]{driver: u.Scheme, repo: repo})
+	ds.openedMu.Unlock()
+
+	ds.notifyOpen(openInfo(u.Scheme, u), time.Since(start))
+
+	return repo, nil
 }